@@ -0,0 +1,198 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLeafCert parses testCertPEM (CN=test, self-signed) for use as a stand-in
+// verified peer certificate.
+func testLeafCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(testCertPEM))
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestConvertClientAuthType(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    tls.ClientAuthType
+		wantErr string
+	}{
+		{name: "empty falls back to default", value: "", want: tls.RequireAndVerifyClientCert},
+		{name: "request", value: "request", want: tls.RequestClientCert},
+		{name: "require_any", value: "require_any", want: tls.RequireAnyClientCert},
+		{name: "verify_if_given", value: "verify_if_given", want: tls.VerifyClientCertIfGiven},
+		{name: "require_and_verify", value: "require_and_verify", want: tls.RequireAndVerifyClientCert},
+		{name: "unknown", value: "nonexistent", wantErr: `unsupported client_auth_type: "nonexistent"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertClientAuthType(tt.value, tls.RequireAndVerifyClientCert)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClientAuthPolicyEmpty(t *testing.T) {
+	assert.True(t, ClientAuthPolicy{}.empty())
+	assert.False(t, ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}}.empty())
+}
+
+func TestClientAuthPolicyMatches(t *testing.T) {
+	cert := testLeafCert(t)
+
+	tests := []struct {
+		name    string
+		policy  ClientAuthPolicy
+		wantErr string
+	}{
+		{
+			name:   "no predicates",
+			policy: ClientAuthPolicy{},
+		},
+		{
+			name:   "matching common name",
+			policy: ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}},
+		},
+		{
+			name:    "non-matching common name",
+			policy:  ClientAuthPolicy{AllowedSubjectCommonNames: []string{"other"}},
+			wantErr: `common name "test" is not allowed`,
+		},
+		{
+			name:    "non-matching organization",
+			policy:  ClientAuthPolicy{AllowedSubjectOrganizations: []string{"Example Corp"}},
+			wantErr: "organization",
+		},
+		{
+			name:    "non-matching serial number",
+			policy:  ClientAuthPolicy{AllowedSerialNumbers: []string{"0"}},
+			wantErr: "serial number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.matches(cert, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClientAuthPolicyMatchesDNRegex(t *testing.T) {
+	cert := testLeafCert(t)
+
+	matching := regexp.MustCompile(`CN=test$`)
+	err := ClientAuthPolicy{}.matches(cert, matching)
+	require.NoError(t, err)
+
+	nonMatching := regexp.MustCompile(`CN=nope$`)
+	err = ClientAuthPolicy{}.matches(cert, nonMatching)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match allowed_subject_dn_regex")
+}
+
+func TestNewClientAuthPolicyVerifierInvalidRegex(t *testing.T) {
+	_, err := newClientAuthPolicyVerifier(ClientAuthPolicy{AllowedSubjectDNRegex: "("})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid allowed_subject_dn_regex")
+}
+
+func TestNewClientAuthPolicyVerifierRejectsMissingCert(t *testing.T) {
+	verify, err := newClientAuthPolicyVerifier(ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}})
+	require.NoError(t, err)
+
+	err = verify(tls.ConnectionState{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not present a certificate")
+}
+
+func TestLoadTLSConfigClientAuthPolicy(t *testing.T) {
+	certFile, keyFile := writeTestCertKeyPair(t)
+	serverSetting := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+		ClientCAFile:     certFile,
+		ClientAuthPolicy: ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}},
+	}
+
+	cfg, _, err := serverSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	require.NotNil(t, cfg.VerifyConnection)
+}
+
+func TestLoadTLSConfigClientAuthPolicyRequiresVerifiedChain(t *testing.T) {
+	certFile, keyFile := writeTestCertKeyPair(t)
+
+	// No ClientCAFile at all: there is no trust store to verify against,
+	// so the policy would run against an unauthenticated certificate.
+	noClientCA := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+		ClientAuthPolicy: ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}},
+	}
+	_, _, err := noClientCA.LoadTLSConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client_auth_policy requires client_ca_file")
+
+	// ClientCAFile is set, but client_auth_type only requires a certificate
+	// be presented, never verifies it against ClientCAFile.
+	requireAnyNoVerify := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+		ClientCAFile:     certFile,
+		ClientAuthType:   "require_any",
+		ClientAuthPolicy: ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}},
+	}
+	_, _, err = requireAnyNoVerify.LoadTLSConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client_auth_policy requires client_ca_file")
+}
+
+func TestLoadTLSConfigClientAuthTypeInvalid(t *testing.T) {
+	certFile, keyFile := writeTestCertKeyPair(t)
+	serverSetting := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+		ClientAuthType: "not_a_real_type",
+	}
+
+	_, _, err := serverSetting.LoadTLSConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid TLS client_auth_type")
+}
@@ -8,10 +8,16 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 // We should avoid that users unknowingly use a vulnerable TLS version.
@@ -47,6 +53,61 @@ type TLSSetting struct {
 	// ReloadInterval specifies the duration after which the certificate will be reloaded
 	// If not set, it will never be reloaded (optional)
 	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+
+	// CAFileReload, if true, watches CAFile for writes/creates/renames and
+	// swaps the RootCAs pool in place as soon as the file changes, instead of
+	// only loading it once at startup. (optional, default false)
+	CAFileReload bool `mapstructure:"ca_file_reload"`
+
+	// CertFileReload, if true, watches CertFile and KeyFile for
+	// writes/creates/renames and reloads the leaf certificate as soon as
+	// either file changes, in addition to any ReloadInterval polling.
+	// (optional, default false)
+	CertFileReload bool `mapstructure:"cert_file_reload"`
+
+	// CipherSuites is an ordered list of cipher suite names that should be
+	// supported. Names must match those returned by tls.CipherSuites() or
+	// tls.InsecureCipherSuites(), e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+	// If empty, the default cipher suites from crypto/tls are used. (optional)
+	CipherSuites []string `mapstructure:"cipher_suites"`
+
+	// CurvePreferences is an ordered list of elliptic curve names to use
+	// during the handshake, e.g. "X25519", "P256", "P384", "P521". If empty,
+	// the default curve preferences from crypto/tls are used. (optional)
+	CurvePreferences []string `mapstructure:"curve_preferences"`
+
+	// Profile selects a named security posture that presets MinVersion,
+	// MaxVersion, CipherSuites and CurvePreferences in one step, similar to
+	// Mozilla's server-side TLS guidelines or Pinniped's ptls profiles.
+	// One of "modern", "intermediate", "old" or "fips". Any of MinVersion,
+	// MaxVersion, CipherSuites or CurvePreferences set explicitly above take
+	// precedence over the profile's value for that field. The "fips"
+	// profile requires the binary to be built with the "boringcrypto" build
+	// tag (GOEXPERIMENT=boringcrypto or -tags boringcrypto) and fails loudly
+	// otherwise. (optional)
+	Profile string `mapstructure:"profile"`
+
+	// OCSP configures OCSP stapling for CertFile/KeyFile, and, on a client,
+	// whether a peer's stapled response is mandatory. (optional)
+	OCSP OCSPSetting `mapstructure:"ocsp"`
+
+	// CRLFiles is a list of paths to CRLs (certificate revocation lists, PEM
+	// or DER). On a client, every verified peer chain's leaf and
+	// intermediates are checked against their union, reloaded on the same
+	// schedule as ReloadInterval. (optional)
+	CRLFiles []string `mapstructure:"crl_files"`
+
+	// SPIFFE sources workload identity from a local SPIRE agent over the
+	// SPIFFE Workload API instead of files on disk. When
+	// SPIFFE.WorkloadAPISocket is set, it takes the place of
+	// CAFile/CertFile/KeyFile, which must then be left empty (setting both
+	// is an error): the X.509-SVID and trust bundle streamed back from the
+	// agent feed GetCertificate/GetClientCertificate and peer chain
+	// verification (ClientCAs on a server, VerifyPeerCertificate on a
+	// client), updated in place as SPIRE rotates them, and peer verification is done
+	// by matching the peer's SPIFFE ID against SPIFFE.AcceptedIDs instead of
+	// by hostname. (optional)
+	SPIFFE SPIFFESetting `mapstructure:"spiffe"`
 }
 
 // TLSClientSetting contains TLS configurations that are specific to client
@@ -83,13 +144,156 @@ type TLSServerSetting struct {
 	// These are config options specific to server connections.
 
 	// Path to the TLS cert to use by the server to verify a client certificate. (optional)
-	// This sets the ClientCAs and ClientAuth to RequireAndVerifyClientCert in the TLSConfig. Please refer to
+	// This sets the ClientCAs in the TLSConfig. Please refer to
 	// https://godoc.org/crypto/tls#Config for more information. (optional)
 	ClientCAFile string `mapstructure:"client_ca_file"`
 
 	// Reload the ClientCAs file when it is modified
 	// (optional, default false)
 	ReloadClientCAFile bool `mapstructure:"client_ca_file_reload"`
+
+	// ClientAuthType sets the server's policy for TLS client authentication,
+	// mapped to tls.ClientAuthType. One of "request", "require_any",
+	// "verify_if_given" or "require_and_verify". If empty, it defaults to
+	// "require_and_verify" when ClientCAFile is set, and to no client
+	// authentication otherwise. (optional)
+	ClientAuthType string `mapstructure:"client_auth_type"`
+
+	// ClientAuthPolicy asserts requirements on the verified peer certificate
+	// beyond "chain valid", such as allow-listing the Subject CN or SAN DNS
+	// names. Every predicate left unset is skipped; a predicate that is set
+	// must match the peer certificate or the handshake is rejected. Only
+	// takes effect once the chain has been verified, so it requires
+	// ClientCAFile and a ClientAuthType of "verify_if_given" or
+	// "require_and_verify" (the default once ClientCAFile is set). (optional)
+	ClientAuthPolicy ClientAuthPolicy `mapstructure:"client_auth_policy"`
+}
+
+// ClientAuthPolicy allow-lists properties of the verified client
+// certificate presented during an mTLS handshake. A handshake is accepted
+// if every predicate the operator set matches; predicates left at their
+// zero value are not enforced. See TLSServerSetting.ClientAuthPolicy.
+type ClientAuthPolicy struct {
+	// AllowedSubjectCommonNames lists the Subject CommonName values a
+	// client certificate may present. (optional)
+	AllowedSubjectCommonNames []string `mapstructure:"allowed_subject_common_names"`
+
+	// AllowedSubjectOrganizations lists the Subject Organization (O) values
+	// a client certificate may present; any one match is sufficient.
+	// (optional)
+	AllowedSubjectOrganizations []string `mapstructure:"allowed_subject_organizations"`
+
+	// AllowedSubjectOrganizationalUnits lists the Subject OrganizationalUnit
+	// (OU) values a client certificate may present; any one match is
+	// sufficient. (optional)
+	AllowedSubjectOrganizationalUnits []string `mapstructure:"allowed_subject_organizational_units"`
+
+	// AllowedSubjectDNRegex is a regular expression matched against the
+	// client certificate's full Subject distinguished name, as rendered by
+	// (pkix.Name).String(). (optional)
+	AllowedSubjectDNRegex string `mapstructure:"allowed_subject_dn_regex"`
+
+	// AllowedSANDNSNames lists SAN DNS names a client certificate may
+	// present; any one match is sufficient. (optional)
+	AllowedSANDNSNames []string `mapstructure:"allowed_san_dns_names"`
+
+	// AllowedSANURIs lists SAN URIs a client certificate may present; any
+	// one match is sufficient. (optional)
+	AllowedSANURIs []string `mapstructure:"allowed_san_uris"`
+
+	// AllowedSerialNumbers lists certificate serial numbers, formatted as
+	// returned by (*big.Int).String(), that a client certificate may
+	// present. (optional)
+	AllowedSerialNumbers []string `mapstructure:"allowed_serial_numbers"`
+}
+
+// empty reports whether no predicate of p is set, meaning the policy does
+// not restrict client certificates beyond standard chain verification.
+func (p ClientAuthPolicy) empty() bool {
+	return len(p.AllowedSubjectCommonNames) == 0 &&
+		len(p.AllowedSubjectOrganizations) == 0 &&
+		len(p.AllowedSubjectOrganizationalUnits) == 0 &&
+		p.AllowedSubjectDNRegex == "" &&
+		len(p.AllowedSANDNSNames) == 0 &&
+		len(p.AllowedSANURIs) == 0 &&
+		len(p.AllowedSerialNumbers) == 0
+}
+
+// matches reports whether cert satisfies every predicate set in p. dnRegex
+// is the pre-compiled form of p.AllowedSubjectDNRegex, compiled once by
+// newClientAuthPolicyVerifier rather than on every handshake.
+func (p ClientAuthPolicy) matches(cert *x509.Certificate, dnRegex *regexp.Regexp) error {
+	if len(p.AllowedSubjectCommonNames) != 0 && !contains(p.AllowedSubjectCommonNames, cert.Subject.CommonName) {
+		return fmt.Errorf("client certificate common name %q is not allowed", cert.Subject.CommonName)
+	}
+	if len(p.AllowedSubjectOrganizations) != 0 && !containsAny(p.AllowedSubjectOrganizations, cert.Subject.Organization) {
+		return fmt.Errorf("client certificate organization %v is not allowed", cert.Subject.Organization)
+	}
+	if len(p.AllowedSubjectOrganizationalUnits) != 0 && !containsAny(p.AllowedSubjectOrganizationalUnits, cert.Subject.OrganizationalUnit) {
+		return fmt.Errorf("client certificate organizational unit %v is not allowed", cert.Subject.OrganizationalUnit)
+	}
+	if dnRegex != nil && !dnRegex.MatchString(cert.Subject.String()) {
+		return fmt.Errorf("client certificate subject %q does not match allowed_subject_dn_regex", cert.Subject.String())
+	}
+	if len(p.AllowedSANDNSNames) != 0 && !containsAny(p.AllowedSANDNSNames, cert.DNSNames) {
+		return fmt.Errorf("client certificate SAN DNS names %v are not allowed", cert.DNSNames)
+	}
+	if len(p.AllowedSANURIs) != 0 && !containsAnyURI(p.AllowedSANURIs, cert.URIs) {
+		return errors.New("client certificate SAN URIs are not allowed")
+	}
+	if len(p.AllowedSerialNumbers) != 0 && !contains(p.AllowedSerialNumbers, cert.SerialNumber.String()) {
+		return fmt.Errorf("client certificate serial number %s is not allowed", cert.SerialNumber.String())
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether any element of values is present in allowed.
+func containsAny(allowed, values []string) bool {
+	for _, v := range values {
+		if contains(allowed, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyURI(allowed []string, uris []*url.URL) bool {
+	for _, u := range uris {
+		if contains(allowed, u.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// newClientAuthPolicyVerifier builds the tls.Config.VerifyConnection hook
+// that enforces policy against the verified peer leaf certificate. Callers
+// must only install it once policy.empty() is false.
+func newClientAuthPolicyVerifier(policy ClientAuthPolicy) (func(tls.ConnectionState) error, error) {
+	var dnRegex *regexp.Regexp
+	if policy.AllowedSubjectDNRegex != "" {
+		var err error
+		dnRegex, err = regexp.Compile(policy.AllowedSubjectDNRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed_subject_dn_regex: %w", err)
+		}
+	}
+
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("client did not present a certificate")
+		}
+		return policy.matches(cs.PeerCertificates[0], dnRegex)
+	}, nil
 }
 
 // certReloader is a wrapper object for certificate reloading
@@ -106,6 +310,8 @@ type certReloader struct {
 	nextReload     time.Time
 	cert           *tls.Certificate
 	lock           sync.RWMutex
+	unregister     func()
+	stopWatching   []func() error
 }
 
 func newCertReloader(certFile, keyFile string, reloadInterval time.Duration) (*certReloader, error) {
@@ -113,13 +319,16 @@ func newCertReloader(certFile, keyFile string, reloadInterval time.Duration) (*c
 	if err != nil {
 		return nil, err
 	}
-	return &certReloader{
+	r := &certReloader{
 		CertFile:       certFile,
 		KeyFile:        keyFile,
 		ReloadInterval: reloadInterval,
 		nextReload:     time.Now().Add(reloadInterval),
 		cert:           &cert,
-	}, nil
+	}
+	r.unregister = register(r)
+	watchSignalsForReload()
+	return r, nil
 }
 
 func (r *certReloader) GetCertificate() (*tls.Certificate, error) {
@@ -133,11 +342,9 @@ func (r *certReloader) GetCertificate() (*tls.Certificate, error) {
 		r.lock.RUnlock()
 		r.lock.Lock()
 		defer r.lock.Unlock()
-		cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load TLS cert and key: %w", err)
+		if err := r.doReload(); err != nil {
+			return nil, err
 		}
-		r.cert = &cert
 		r.nextReload = now.Add(r.ReloadInterval)
 		return r.cert, nil
 	}
@@ -145,44 +352,396 @@ func (r *certReloader) GetCertificate() (*tls.Certificate, error) {
 	return r.cert, nil
 }
 
+// reload unconditionally re-reads CertFile/KeyFile from disk, regardless of
+// ReloadInterval. It is called by ReloadAll on SIGHUP.
+func (r *certReloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.doReload()
+}
+
+// doReload re-reads CertFile/KeyFile from disk. Callers must hold r.lock.
+func (r *certReloader) doReload() error {
+	cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS cert and key: %w", err)
+	}
+	r.cert = &cert
+	return nil
+}
+
+func (r *certReloader) paths() []string {
+	return []string{r.CertFile, r.KeyFile}
+}
+
+// startWatching watches CertFile and KeyFile and reloads the certificate as
+// soon as either changes, rather than waiting for the next ReloadInterval.
+func (r *certReloader) startWatching() error {
+	onChange := func() {
+		if err := r.reload(); err != nil {
+			zap.L().Error("failed to reload TLS cert and key", zap.String("cert_file", r.CertFile), zap.Error(err))
+		}
+	}
+	stopCert, err := watchFile(r.CertFile, onChange)
+	if err != nil {
+		return err
+	}
+	r.stopWatching = append(r.stopWatching, stopCert)
+	stopKey, err := watchFile(r.KeyFile, onChange)
+	if err != nil {
+		return err
+	}
+	r.stopWatching = append(r.stopWatching, stopKey)
+	return nil
+}
+
+// Close removes r from the package-level reload registry and stops any
+// fsnotify watchers started by startWatching, so ReloadAll, SIGHUP, and the
+// watcher goroutines no longer touch it once the owning TLS config is no
+// longer in use.
+func (r *certReloader) Close() error {
+	r.unregister()
+	var firstErr error
+	for _, stop := range r.stopWatching {
+		if err := stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// caReloader is a wrapper object for the root CA pool loaded from
+// TLSSetting.CAFile. Unlike the one-shot load done by TLSSetting.loadCert,
+// it keeps the pool it returns up to date when CAFileReload is enabled.
+type caReloader struct {
+	caFile       string
+	certPool     *x509.CertPool
+	lock         sync.RWMutex
+	unregister   func()
+	stopWatching func() error
+}
+
+func newCAReloader(caFile string) (*caReloader, error) {
+	r := &caReloader{caFile: caFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	r.unregister = register(r)
+	watchSignalsForReload()
+	return r, nil
+}
+
+func (r *caReloader) reload() error {
+	certPool, err := loadCertPool(r.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to load CA CertPool: %w", err)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.certPool = certPool
+	return nil
+}
+
+func (r *caReloader) paths() []string {
+	return []string{r.caFile}
+}
+
+// get returns the RootCAs pool currently in effect. It is safe to call
+// concurrently with a reload triggered by startWatching or ReloadAll.
+func (r *caReloader) get() *x509.CertPool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.certPool
+}
+
+func (r *caReloader) startWatching() error {
+	stop, err := watchFile(r.caFile, func() {
+		if err := r.reload(); err != nil {
+			zap.L().Error("failed to reload CA file", zap.String("path", r.caFile), zap.Error(err))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	r.stopWatching = stop
+	return nil
+}
+
+// Close removes r from the package-level reload registry and stops the
+// fsnotify watcher started by startWatching, so ReloadAll, SIGHUP, and the
+// watcher goroutine no longer touch it once the owning TLS config is no
+// longer in use.
+func (r *caReloader) Close() error {
+	r.unregister()
+	if r.stopWatching != nil {
+		return r.stopWatching()
+	}
+	return nil
+}
+
+// clientCAsReloader is a wrapper object for the client CA pool used by a
+// TLSServerSetting. Its certPool field holds the currently active pool; when
+// ReloadClientCAFile is set, startWatching keeps it up to date.
+type clientCAsReloader struct {
+	caFile       string
+	settings     *TLSServerSetting
+	certPool     *x509.CertPool
+	lock         sync.RWMutex
+	unregister   func()
+	stopWatching func() error
+}
+
+func newClientCAsReloader(caFile string, settings *TLSServerSetting) (*clientCAsReloader, error) {
+	r := &clientCAsReloader{
+		caFile:   caFile,
+		settings: settings,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	r.unregister = register(r)
+	watchSignalsForReload()
+	return r, nil
+}
+
+func (r *clientCAsReloader) paths() []string {
+	return []string{r.caFile}
+}
+
+func (r *clientCAsReloader) reload() error {
+	certPool, err := r.settings.loadClientCAFile()
+	if err != nil {
+		return fmt.Errorf("failed to load client CA CertPool: %w", err)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.certPool = certPool
+	return nil
+}
+
+func (r *clientCAsReloader) startWatching() error {
+	stop, err := watchFile(r.caFile, func() {
+		if err := r.reload(); err != nil {
+			zap.L().Error("failed to reload client CA file", zap.String("path", r.caFile), zap.Error(err))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	r.stopWatching = stop
+	return nil
+}
+
+// Close removes r from the package-level reload registry and stops the
+// fsnotify watcher started by startWatching, so ReloadAll, SIGHUP, and the
+// watcher goroutine no longer touch it once the owning TLS config is no
+// longer in use.
+func (r *clientCAsReloader) Close() error {
+	r.unregister()
+	if r.stopWatching != nil {
+		return r.stopWatching()
+	}
+	return nil
+}
+
+// get returns the ClientCAs pool currently in effect. It is safe to call
+// concurrently with a reload triggered by startWatching or ReloadAll.
+func (r *clientCAsReloader) get() *x509.CertPool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.certPool
+}
+
+// tlsProfile is a named bundle of TLSSetting defaults. Profiles only ever
+// fill in fields the user left unset; see withProfileDefaults.
+type tlsProfile struct {
+	minVersion       string
+	maxVersion       string
+	cipherSuites     []string
+	curvePreferences []string
+}
+
+// tlsProfiles mirrors the tiers in Mozilla's server-side TLS guidelines,
+// plus a "fips" tier restricted to FIPS 140-2 approved algorithms. Cipher
+// suite names match those returned by tls.CipherSuites() /
+// tls.InsecureCipherSuites(); TLS 1.3 suites are omitted since crypto/tls
+// selects among them automatically and does not allow configuring them.
+var tlsProfiles = map[string]tlsProfile{
+	"modern": {
+		minVersion:       "1.3",
+		curvePreferences: []string{"X25519", "P256"},
+	},
+	"intermediate": {
+		minVersion: "1.2",
+		cipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+			"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		},
+		curvePreferences: []string{"X25519", "P256", "P384"},
+	},
+	"old": {
+		minVersion: "1.0",
+		cipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+			"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+			"TLS_RSA_WITH_AES_128_CBC_SHA",
+			"TLS_RSA_WITH_AES_256_CBC_SHA",
+		},
+	},
+	"fips": {
+		minVersion: "1.2",
+		cipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		},
+		curvePreferences: []string{"P256", "P384"},
+	},
+}
+
+// withProfileDefaults returns a copy of c with MinVersion, MaxVersion,
+// CipherSuites and CurvePreferences filled in from c.Profile wherever c
+// left that field unset. Fields the user set explicitly always win. It is
+// a no-op when c.Profile is empty.
+func (c TLSSetting) withProfileDefaults() (TLSSetting, error) {
+	if c.Profile == "" {
+		return c, nil
+	}
+	profile, ok := tlsProfiles[c.Profile]
+	if !ok {
+		return TLSSetting{}, fmt.Errorf("unsupported TLS profile: %q", c.Profile)
+	}
+	if c.Profile == "fips" && !fipsCapable {
+		return TLSSetting{}, errors.New(`the "fips" profile requires a FIPS 140-2 capable crypto backend; rebuild with the "boringcrypto" build tag or GOEXPERIMENT=boringcrypto`)
+	}
+
+	if c.MinVersion == "" {
+		c.MinVersion = profile.minVersion
+	}
+	if c.MaxVersion == "" {
+		c.MaxVersion = profile.maxVersion
+	}
+	if len(c.CipherSuites) == 0 {
+		c.CipherSuites = profile.cipherSuites
+	}
+	if len(c.CurvePreferences) == 0 {
+		c.CurvePreferences = profile.curvePreferences
+	}
+	return c, nil
+}
+
 // LoadTLSConfig loads TLS certificates and returns a tls.Config.
-// This will set the RootCAs and Certificates of a tls.Config.
-func (c TLSSetting) loadTLSConfig() (*tls.Config, error) {
+// This will set the RootCAs and Certificates of a tls.Config. The returned
+// *caReloader is non-nil when c.CAFileReload is set, so that callers can wire
+// up the rotation hook appropriate to their role (client or server). The
+// returned tlsCloser releases every reloader created along the way
+// (registered reload hooks and any fsnotify watchers); callers must Close it
+// once the TLS config is no longer in use, even on a subsequent error, to
+// avoid leaking them.
+func (c TLSSetting) loadTLSConfig() (*tls.Config, *caReloader, tlsCloser, error) {
+	c, err := c.withProfileDefaults()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid TLS profile: %w", err)
+	}
+
 	// There is no need to load the System Certs for RootCAs because
 	// if the value is nil, it will default to checking against th System Certs.
-	var err error
 	var certPool *x509.CertPool
+	var caRel *caReloader
+	var closer tlsCloser
 	if len(c.CAFile) != 0 {
-		// Set up user specified truststore.
-		certPool, err = c.loadCert(c.CAFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load CA CertPool: %w", err)
+		if c.CAFileReload {
+			caRel, err = newCAReloader(c.CAFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to load CA CertPool: %w", err)
+			}
+			closer = append(closer, caRel)
+			if err = caRel.startWatching(); err != nil {
+				closer.Close()
+				return nil, nil, nil, err
+			}
+			certPool = caRel.get()
+		} else {
+			// Set up user specified truststore.
+			certPool, err = c.loadCert(c.CAFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to load CA CertPool: %w", err)
+			}
 		}
 	}
 
 	if (c.CertFile == "" && c.KeyFile != "") || (c.CertFile != "" && c.KeyFile == "") {
-		return nil, errors.New("for auth via TLS, either both certificate and key must be supplied, or neither")
+		closer.Close()
+		return nil, nil, nil, errors.New("for auth via TLS, either both certificate and key must be supplied, or neither")
 	}
 
 	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 	var getClientCertificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
 	if c.CertFile != "" && c.KeyFile != "" {
-		var certReloader *certReloader
-		certReloader, err = newCertReloader(c.CertFile, c.KeyFile, c.ReloadInterval)
+		var reloader *certReloader
+		reloader, err = newCertReloader(c.CertFile, c.KeyFile, c.ReloadInterval)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load TLS cert and key: %w", err)
+			closer.Close()
+			return nil, nil, nil, fmt.Errorf("failed to load TLS cert and key: %w", err)
+		}
+		closer = append(closer, reloader)
+		if c.CertFileReload {
+			if err = reloader.startWatching(); err != nil {
+				closer.Close()
+				return nil, nil, nil, err
+			}
+		}
+		getCertificate = func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) { return reloader.GetCertificate() }
+		getClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) { return reloader.GetCertificate() }
+
+		if c.OCSP.Enabled {
+			var stapler *ocspStapler
+			stapler, err = newOCSPStapler(reloader, c.OCSP)
+			if err != nil {
+				closer.Close()
+				return nil, nil, nil, fmt.Errorf("failed to prefetch OCSP staple: %w", err)
+			}
+			closer = append(closer, stapler)
+			getCertificate = func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, err := reloader.GetCertificate()
+				if err != nil {
+					return nil, err
+				}
+				stapled := *cert
+				stapled.OCSPStaple = stapler.GetStaple()
+				return &stapled, nil
+			}
 		}
-		getCertificate = func(chi *tls.ClientHelloInfo) (*tls.Certificate, error) { return certReloader.GetCertificate() }
-		getClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) { return certReloader.GetCertificate() }
 	}
 
 	minTLS, err := convertVersion(c.MinVersion, defaultMinTLSVersion)
 	if err != nil {
-		return nil, fmt.Errorf("invalid TLS min_version: %w", err)
+		closer.Close()
+		return nil, nil, nil, fmt.Errorf("invalid TLS min_version: %w", err)
 	}
 	maxTLS, err := convertVersion(c.MaxVersion, defaultMaxTLSVersion)
 	if err != nil {
-		return nil, fmt.Errorf("invalid TLS max_version: %w", err)
+		closer.Close()
+		return nil, nil, nil, fmt.Errorf("invalid TLS max_version: %w", err)
+	}
+	cipherSuites, err := convertCipherSuites(c.CipherSuites)
+	if err != nil {
+		closer.Close()
+		return nil, nil, nil, fmt.Errorf("invalid TLS cipher_suites: %w", err)
+	}
+	curvePreferences, err := convertCurvePreferences(c.CurvePreferences)
+	if err != nil {
+		closer.Close()
+		return nil, nil, nil, fmt.Errorf("invalid TLS curve_preferences: %w", err)
 	}
 
 	return &tls.Config{
@@ -191,10 +750,16 @@ func (c TLSSetting) loadTLSConfig() (*tls.Config, error) {
 		GetClientCertificate: getClientCertificate,
 		MinVersion:           minTLS,
 		MaxVersion:           maxTLS,
-	}, nil
+		CipherSuites:         cipherSuites,
+		CurvePreferences:     curvePreferences,
+	}, caRel, closer, nil
 }
 
 func (c TLSSetting) loadCert(caPath string) (*x509.CertPool, error) {
+	return loadCertPool(caPath)
+}
+
+func loadCertPool(caPath string) (*x509.CertPool, error) {
 	caPEM, err := os.ReadFile(filepath.Clean(caPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load CA %s: %w", caPath, err)
@@ -207,43 +772,186 @@ func (c TLSSetting) loadCert(caPath string) (*x509.CertPool, error) {
 	return certPool, nil
 }
 
-// LoadTLSConfig loads the TLS configuration.
-func (c TLSClientSetting) LoadTLSConfig() (*tls.Config, error) {
+// LoadTLSConfig loads the TLS configuration. The returned io.Closer releases
+// every reloader, file watcher, or live connection the config wired up;
+// callers must Close it once the TLS config is no longer in use.
+func (c TLSClientSetting) LoadTLSConfig() (*tls.Config, io.Closer, error) {
 	if c.Insecure && c.CAFile == "" {
-		return nil, nil
+		return nil, tlsCloser{}, nil
 	}
 
-	tlsCfg, err := c.TLSSetting.loadTLSConfig()
+	tlsCfg, caRel, closer, err := c.TLSSetting.loadTLSConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load TLS config: %w", err)
+		return nil, tlsCloser{}, fmt.Errorf("failed to load TLS config: %w", err)
 	}
 	tlsCfg.ServerName = c.ServerName
 	tlsCfg.InsecureSkipVerify = c.InsecureSkipVerify
-	return tlsCfg, nil
+	if caRel != nil {
+		wireClientRootCAsReload(tlsCfg, caRel)
+	}
+
+	if c.SPIFFE.enabled() {
+		spiffeCloser, err := c.wireSPIFFE(tlsCfg)
+		if err != nil {
+			closer.Close()
+			return nil, tlsCloser{}, fmt.Errorf("failed to configure SPIFFE: %w", err)
+		}
+		closer = append(closer, spiffeCloser)
+	}
+
+	var crl *crlChecker
+	if len(c.CRLFiles) != 0 {
+		crl, err = newCRLChecker(c.CRLFiles, c.ReloadInterval)
+		if err != nil {
+			closer.Close()
+			return nil, tlsCloser{}, fmt.Errorf("failed to load CRL files: %w", err)
+		}
+		closer = append(closer, crl)
+	}
+	if c.OCSP.RequireStapling || crl != nil {
+		tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if c.OCSP.RequireStapling {
+				if err := checkOCSPStaple(cs); err != nil {
+					return err
+				}
+			}
+			if crl != nil {
+				chains := cs.VerifiedChains
+				if len(chains) == 0 {
+					chains = [][]*x509.Certificate{cs.PeerCertificates}
+				}
+				for _, chain := range chains {
+					if err := crl.checkChain(chain); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+	}
+	return tlsCfg, closer, nil
+}
+
+// wireClientRootCAsReload makes tlsCfg honor caRel's RootCAs pool as it is
+// rotated, rather than the snapshot taken when tlsCfg was built. Client-side
+// tls.Config has no GetConfigForClient equivalent, so verification is done
+// explicitly against the live pool in VerifyPeerCertificate, following the
+// pattern documented by crypto/tls for custom chain verification.
+func wireClientRootCAsReload(tlsCfg *tls.Config, caRel *caReloader) {
+	skipVerify := tlsCfg.InsecureSkipVerify
+	serverName := tlsCfg.ServerName
+	tlsCfg.InsecureSkipVerify = true
+	// RootCAs is superseded by caRel: crypto/tls ignores it once
+	// InsecureSkipVerify is true, and VerifyPeerCertificate below always
+	// consults the live pool instead of this snapshot.
+	tlsCfg.RootCAs = nil
+	tlsCfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if skipVerify {
+			return nil
+		}
+		leaf, intermediates, err := parsePeerCertificates(rawCerts)
+		if err != nil {
+			return err
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         caRel.get(),
+			Intermediates: intermediates,
+		})
+		return err
+	}
 }
 
-// LoadTLSConfig loads the TLS configuration.
-func (c TLSServerSetting) LoadTLSConfig() (*tls.Config, error) {
-	tlsCfg, err := c.loadTLSConfig()
+func parsePeerCertificates(rawCerts [][]byte) (leaf *x509.Certificate, intermediates *x509.CertPool, err error) {
+	if len(rawCerts) == 0 {
+		return nil, nil, errors.New("no peer certificates presented")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse peer certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+	intermediates = x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	return certs[0], intermediates, nil
+}
+
+// LoadTLSConfig loads the TLS configuration. The returned io.Closer releases
+// every reloader, file watcher, or live connection the config wired up;
+// callers must Close it once the TLS config is no longer in use.
+func (c TLSServerSetting) LoadTLSConfig() (*tls.Config, io.Closer, error) {
+	tlsCfg, caRel, closer, err := c.loadTLSConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load TLS config: %w", err)
+		return nil, tlsCloser{}, fmt.Errorf("failed to load TLS config: %w", err)
 	}
+
+	var clientCAsRel *clientCAsReloader
 	if c.ClientCAFile != "" {
-		reloader, err := newClientCAsReloader(c.ClientCAFile, &c)
+		clientCAsRel, err = newClientCAsReloader(c.ClientCAFile, &c)
 		if err != nil {
-			return nil, err
+			closer.Close()
+			return nil, tlsCloser{}, err
 		}
+		closer = append(closer, clientCAsRel)
 		if c.ReloadClientCAFile {
-			err = reloader.startWatching()
-			if err != nil {
-				return nil, err
+			if err = clientCAsRel.startWatching(); err != nil {
+				closer.Close()
+				return nil, tlsCloser{}, err
 			}
-			tlsCfg.GetConfigForClient = func(t *tls.ClientHelloInfo) (*tls.Config, error) { return reloader.getClientConfig(tlsCfg) }
 		}
-		tlsCfg.ClientCAs = reloader.certPool
-		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsCfg.ClientCAs = clientCAsRel.get()
+	}
+
+	defaultClientAuth := tls.NoClientCert
+	if c.ClientCAFile != "" {
+		defaultClientAuth = tls.RequireAndVerifyClientCert
+	}
+	tlsCfg.ClientAuth, err = convertClientAuthType(c.ClientAuthType, defaultClientAuth)
+	if err != nil {
+		closer.Close()
+		return nil, tlsCloser{}, fmt.Errorf("invalid TLS client_auth_type: %w", err)
 	}
-	return tlsCfg, nil
+
+	if !c.ClientAuthPolicy.empty() {
+		if c.ClientCAFile == "" || (tlsCfg.ClientAuth != tls.VerifyClientCertIfGiven && tlsCfg.ClientAuth != tls.RequireAndVerifyClientCert) {
+			closer.Close()
+			return nil, tlsCloser{}, errors.New("client_auth_policy requires client_ca_file and a client_auth_type of verify_if_given or require_and_verify, otherwise it runs against an unverified certificate chain")
+		}
+		verifyConnection, err := newClientAuthPolicyVerifier(c.ClientAuthPolicy)
+		if err != nil {
+			closer.Close()
+			return nil, tlsCloser{}, fmt.Errorf("invalid TLS client_auth_policy: %w", err)
+		}
+		tlsCfg.VerifyConnection = verifyConnection
+	}
+
+	if c.SPIFFE.enabled() {
+		spiffeCloser, err := c.wireSPIFFE(tlsCfg)
+		if err != nil {
+			closer.Close()
+			return nil, tlsCloser{}, fmt.Errorf("failed to configure SPIFFE: %w", err)
+		}
+		closer = append(closer, spiffeCloser)
+	}
+
+	if caRel != nil || (clientCAsRel != nil && c.ReloadClientCAFile) {
+		tlsCfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := tlsCfg.Clone()
+			if caRel != nil {
+				cfg.RootCAs = caRel.get()
+			}
+			if clientCAsRel != nil {
+				cfg.ClientCAs = clientCAsRel.get()
+			}
+			return cfg, nil
+		}
+	}
+	return tlsCfg, closer, nil
 }
 
 func (c TLSServerSetting) loadClientCAFile() (*x509.CertPool, error) {
@@ -268,3 +976,99 @@ var tlsVersions = map[string]uint16{
 	"1.2": tls.VersionTLS12,
 	"1.3": tls.VersionTLS13,
 }
+
+// convertClientAuthType resolves client_auth_type to its tls.ClientAuthType,
+// falling back to defaultAuth when it is unset.
+func convertClientAuthType(v string, defaultAuth tls.ClientAuthType) (tls.ClientAuthType, error) {
+	if v == "" {
+		return defaultAuth, nil
+	}
+	auth, ok := clientAuthTypes[v]
+	if !ok {
+		return tls.NoClientCert, fmt.Errorf("unsupported client_auth_type: %q", v)
+	}
+	return auth, nil
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"request":            tls.RequestClientCert,
+	"require_any":        tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// convertCipherSuites resolves a list of human-readable cipher suite names,
+// as reported by tls.CipherSuites() and tls.InsecureCipherSuites(), to their
+// corresponding IDs. An empty input returns a nil slice so that crypto/tls
+// falls back to its own default suite selection.
+func convertCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids, unsupported := lookupCipherSuites(names)
+	if len(unsupported) != 0 {
+		return nil, fmt.Errorf("unsupported cipher suite(s): %s", strings.Join(unsupported, ", "))
+	}
+	return ids, nil
+}
+
+func lookupCipherSuites(names []string) ([]uint16, []string) {
+	ids := make([]uint16, 0, len(names))
+	var unsupported []string
+	for _, name := range names {
+		id, ok := supportedCipherSuites()[name]
+		if !ok {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, unsupported
+}
+
+// convertCurvePreferences resolves a list of human-readable elliptic curve
+// names to the tls.CurveID values crypto/tls expects. An empty input
+// returns a nil slice so that crypto/tls falls back to its own default
+// curve preferences.
+func convertCurvePreferences(names []string) ([]tls.CurveID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]tls.CurveID, 0, len(names))
+	var unsupported []string
+	for _, name := range names {
+		id, ok := tlsCurveIDs[name]
+		if !ok {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(unsupported) != 0 {
+		return nil, fmt.Errorf("unsupported curve(s): %s", strings.Join(unsupported, ", "))
+	}
+	return ids, nil
+}
+
+var tlsCurveIDs = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+}
+
+// supportedCipherSuites returns a map of cipher suite name to ID built from
+// both the secure and insecure suites known to crypto/tls, so operators can
+// be explicit about allowing a weak suite if they really need to.
+func supportedCipherSuites() map[string]uint16 {
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	return suites
+}
@@ -0,0 +1,232 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertCipherSuites(t *testing.T) {
+	tests := []struct {
+		name    string
+		suites  []string
+		wantErr string
+	}{
+		{
+			name:   "empty",
+			suites: nil,
+		},
+		{
+			name:   "valid secure suite",
+			suites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		},
+		{
+			name:   "valid insecure suite",
+			suites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+		},
+		{
+			name:    "unknown suite",
+			suites:  []string{"NOT_A_REAL_CIPHER_SUITE"},
+			wantErr: "unsupported cipher suite(s): NOT_A_REAL_CIPHER_SUITE",
+		},
+		{
+			name:    "mixed valid and unknown",
+			suites:  []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "NOT_A_REAL_CIPHER_SUITE"},
+			wantErr: "unsupported cipher suite(s): NOT_A_REAL_CIPHER_SUITE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := convertCipherSuites(tt.suites)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, ids, len(tt.suites))
+		})
+	}
+}
+
+func TestLoadTLSConfigCipherSuites(t *testing.T) {
+	setting := TLSSetting{
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	cfg, _, _, err := setting.loadTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+}
+
+// TestLoadTLSConfigCipherSuitesNegotiated drives a real loopback handshake
+// and confirms that only the configured cipher suite is ever negotiated,
+// not merely that it is passed through to tls.Config. CipherSuites only
+// constrains negotiation below TLS 1.3, so both sides are pinned to 1.2.
+func TestLoadTLSConfigCipherSuitesNegotiated(t *testing.T) {
+	certFile, keyFile := writeTestCertKeyPair(t)
+
+	serverSetting := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			MinVersion:   "1.2",
+			MaxVersion:   "1.2",
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		},
+	}
+	serverCfg, serverCloser, err := serverSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	defer serverCloser.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	serverDone := make(chan error, 1)
+	go func() {
+		conn, acceptErr := ln.Accept()
+		if acceptErr != nil {
+			serverDone <- acceptErr
+			return
+		}
+		defer conn.Close()
+		serverDone <- conn.(*tls.Conn).Handshake()
+	}()
+
+	clientSetting := TLSClientSetting{
+		TLSSetting: TLSSetting{
+			MinVersion:   "1.2",
+			MaxVersion:   "1.2",
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		},
+		// testCertPEM has no SAN, only a legacy CN, so hostname
+		// verification is skipped here; cipher suite negotiation is
+		// independent of certificate verification.
+		InsecureSkipVerify: true,
+	}
+	clientCfg, clientCloser, err := clientSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	defer clientCloser.Close()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.Handshake())
+	require.NoError(t, <-serverDone)
+
+	assert.Equal(t, uint16(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256), conn.ConnectionState().CipherSuite)
+}
+
+func TestLoadTLSConfigCipherSuitesInvalid(t *testing.T) {
+	setting := TLSSetting{
+		CipherSuites: []string{"not_a_cipher_suite"},
+	}
+	_, _, _, err := setting.loadTLSConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid TLS cipher_suites")
+}
+
+func TestConvertCurvePreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		curves  []string
+		wantErr string
+	}{
+		{
+			name:   "empty",
+			curves: nil,
+		},
+		{
+			name:   "valid curves",
+			curves: []string{"X25519", "P256", "P384", "P521"},
+		},
+		{
+			name:    "unknown curve",
+			curves:  []string{"NOT_A_REAL_CURVE"},
+			wantErr: "unsupported curve(s): NOT_A_REAL_CURVE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := convertCurvePreferences(tt.curves)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, ids, len(tt.curves))
+		})
+	}
+}
+
+func TestLoadTLSConfigCurvePreferences(t *testing.T) {
+	setting := TLSSetting{
+		CurvePreferences: []string{"X25519", "P256"},
+	}
+	cfg, _, _, err := setting.loadTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []tls.CurveID{tls.X25519, tls.CurveP256}, cfg.CurvePreferences)
+}
+
+func TestLoadTLSConfigCurvePreferencesInvalid(t *testing.T) {
+	setting := TLSSetting{
+		CurvePreferences: []string{"not_a_curve"},
+	}
+	_, _, _, err := setting.loadTLSConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid TLS curve_preferences")
+}
+
+func TestTLSSettingProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr string
+	}{
+		{name: "modern", profile: "modern"},
+		{name: "intermediate", profile: "intermediate"},
+		{name: "old", profile: "old"},
+		{
+			name:    "fips without fips-capable build",
+			profile: "fips",
+			wantErr: `the "fips" profile requires a FIPS 140-2 capable crypto backend`,
+		},
+		{
+			name:    "unknown profile",
+			profile: "nonexistent",
+			wantErr: `unsupported TLS profile: "nonexistent"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := TLSSetting{Profile: tt.profile}
+			cfg, _, _, err := setting.loadTLSConfig()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotZero(t, cfg.MinVersion)
+		})
+	}
+}
+
+func TestTLSSettingProfileExplicitFieldsWin(t *testing.T) {
+	setting := TLSSetting{
+		Profile:    "modern",
+		MinVersion: "1.2",
+	}
+	cfg, _, _, err := setting.loadTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+}
@@ -0,0 +1,12 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build boringcrypto
+
+package configtls // import "go.opentelemetry.io/collector/config/configtls"
+
+// fipsCapable reports whether this binary was built against a FIPS 140-2
+// validated crypto backend. Built with the "boringcrypto" tag (or
+// GOEXPERIMENT=boringcrypto), crypto/tls itself is restricted to FIPS
+// approved algorithms, so the "fips" TLSSetting.Profile is safe to use.
+const fipsCapable = true
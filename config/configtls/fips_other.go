@@ -0,0 +1,12 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !boringcrypto
+
+package configtls // import "go.opentelemetry.io/collector/config/configtls"
+
+// fipsCapable reports whether this binary was built against a FIPS 140-2
+// validated crypto backend. See fips_boringcrypto.go for the build that
+// satisfies it; ordinary builds are not FIPS capable, so the "fips"
+// TLSSetting.Profile must be rejected rather than silently relaxed.
+const fipsCapable = false
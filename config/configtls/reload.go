@@ -0,0 +1,147 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadable is implemented by the internal reload wrapper types
+// (certReloader, clientCAsReloader, caReloader, ocspStapler and crlChecker)
+// so that they can be
+// tracked in the package-level registry and forced to reload outside of
+// their normal ReloadInterval/fsnotify triggers.
+type reloadable interface {
+	reload() error
+	paths() []string
+}
+
+// watchFile starts a goroutine that watches the directory containing path
+// and calls onChange whenever path itself is written, created, or renamed
+// into place - the latter being the common way config/secret managers
+// atomically replace a file in place. The returned stop function closes the
+// watcher and ends the goroutine; callers must call it once the watch is no
+// longer needed.
+func watchFile(path string, onChange func()) (stop func() error, err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start watching %s: %w", path, err)
+	}
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+	go func() {
+		for event := range watcher.Events {
+			if filepath.Clean(event.Name) != filepath.Clean(path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			onChange()
+		}
+	}()
+	return watcher.Close, nil
+}
+
+var registry = struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]reloadable
+}{entries: make(map[int64]reloadable)}
+
+// register adds r to the package-level registry of active reloaders and
+// returns a function that removes it again. Callers should invoke the
+// returned function once the reloader is no longer in use (e.g. when the
+// owning TLS config is torn down) so ReloadAll does not keep reloading a
+// stale file.
+func register(r reloadable) (unregister func()) {
+	registry.mu.Lock()
+	id := registry.nextID
+	registry.nextID++
+	registry.entries[id] = r
+	registry.mu.Unlock()
+
+	return func() {
+		registry.mu.Lock()
+		delete(registry.entries, id)
+		registry.mu.Unlock()
+	}
+}
+
+// ReloadAll forces every reloader currently registered (i.e. in use by a
+// loaded TLSSetting/TLSServerSetting) to re-read its files, or refetch its
+// OCSP staple, immediately, regardless of ReloadInterval. It is safe to
+// call concurrently and logs one structured success/failure entry per
+// reloader, listing the paths that reloader covers.
+func ReloadAll() {
+	registry.mu.Lock()
+	reloaders := make([]reloadable, 0, len(registry.entries))
+	for _, r := range registry.entries {
+		reloaders = append(reloaders, r)
+	}
+	registry.mu.Unlock()
+
+	for _, r := range reloaders {
+		err := r.reload()
+		logger := zap.L().With(zap.Strings("paths", r.paths()))
+		if err != nil {
+			logger.Error("failed to reload TLS material", zap.Error(err))
+			continue
+		}
+		logger.Info("reloaded TLS material")
+	}
+}
+
+// tlsCloser aggregates the Close methods of every reloader, file watcher, or
+// live connection (e.g. a SPIFFE Workload API source) wired into a loaded
+// TLS config, so LoadTLSConfig can hand callers a single handle to release
+// them all once the owning component shuts down or reloads its config,
+// instead of leaking them in the package-level registry and any fsnotify
+// watchers forever.
+type tlsCloser []io.Closer
+
+// Close closes every closer in c, continuing past a failure so one broken
+// resource does not prevent the rest from being released, and returns the
+// first error encountered, if any.
+func (c tlsCloser) Close() error {
+	var firstErr error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+var watchSignalsOnce sync.Once
+
+// watchSignalsForReload starts a goroutine that calls ReloadAll whenever the
+// process receives SIGHUP, giving operators a Nomad-style "reload on SIGHUP"
+// workflow for zero-downtime cert rotation. It is started automatically the
+// first time a TLSSetting with CertFile/KeyFile, CAFileReload or CRLFiles,
+// or a TLSServerSetting with ClientCAFile, is loaded, and is otherwise a
+// no-op on repeated calls.
+func watchSignalsForReload() {
+	watchSignalsOnce.Do(func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGHUP)
+		go func() {
+			for range sigCh {
+				zap.L().Info("received SIGHUP, reloading TLS material")
+				ReloadAll()
+			}
+		}()
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCAReloaderPicksUpRotatedFile rewrites the CA bundle on disk after the
+// reloader has already loaded it once and confirms the watcher swaps in a
+// pool built from the new file contents, without the caller rebuilding
+// anything.
+func TestCAReloaderPicksUpRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte(testCertPEM), 0o600))
+
+	r, err := newCAReloader(caFile)
+	require.NoError(t, err)
+	require.NoError(t, r.startWatching())
+
+	original := r.get()
+	require.NotNil(t, original)
+
+	require.NoError(t, os.WriteFile(caFile, []byte(rotatedCertPEM), 0o600))
+
+	require.Eventually(t, func() bool {
+		return !r.get().Equal(original)
+	}, 2*time.Second, 20*time.Millisecond, "expected pool to change after CA file rewrite")
+}
+
+// TestTLSClientSettingWiresLiveRootCAs confirms that enabling CAFileReload on
+// a client setting swaps in VerifyPeerCertificate backed by the live pool
+// instead of the one-shot RootCAs snapshot.
+func TestTLSClientSettingWiresLiveRootCAs(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte(testCertPEM), 0o600))
+
+	clientSetting := TLSClientSetting{
+		TLSSetting: TLSSetting{
+			CAFile:       caFile,
+			CAFileReload: true,
+		},
+		ServerName: "test",
+	}
+	clientCfg, _, err := clientSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	assert.True(t, clientCfg.InsecureSkipVerify)
+	assert.NotNil(t, clientCfg.VerifyPeerCertificate)
+	assert.Nil(t, clientCfg.RootCAs, "rotation is driven by VerifyPeerCertificate, not a static RootCAs snapshot")
+}
+
+// TestTLSServerSettingWiresLiveRootCAs confirms that enabling CAFileReload on
+// a server setting produces a GetConfigForClient hook that reflects the
+// live RootCAs pool.
+func TestTLSServerSettingWiresLiveRootCAs(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile, keyFile := writeTestCertKeyPair(t)
+	require.NoError(t, os.WriteFile(caFile, []byte(testCertPEM), 0o600))
+
+	serverSetting := TLSServerSetting{
+		TLSSetting: TLSSetting{
+			CAFile:       caFile,
+			CAFileReload: true,
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+		},
+	}
+	serverCfg, _, err := serverSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	require.NotNil(t, serverCfg.GetConfigForClient)
+
+	cfg, err := serverCfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.RootCAs)
+}
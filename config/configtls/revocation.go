@@ -0,0 +1,334 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls // import "go.opentelemetry.io/collector/config/configtls"
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrCertificateRevoked is returned, wrapped with the offending serial
+// number, when a peer certificate is found revoked by a stapled OCSP
+// response or a configured CRL. Receivers can match it with errors.Is to
+// log revocation failures distinctly from other handshake errors.
+var ErrCertificateRevoked = errors.New("certificate is revoked")
+
+// ErrOCSPStaplingRequired is returned when TLSSetting.OCSP.RequireStapling
+// is set but the peer did not present a stapled OCSP response.
+var ErrOCSPStaplingRequired = errors.New("peer did not provide a required OCSP staple")
+
+// OCSPSetting configures OCSP stapling. On a server, a fresh OCSP response
+// for the configured leaf certificate is prefetched and kept up to date,
+// then attached to the handshake via tls.Certificate.OCSPStaple. On a
+// client, it additionally controls whether the server's staple is
+// mandatory. See TLSSetting.OCSP.
+type OCSPSetting struct {
+	// Enabled turns on OCSP stapling for the server leaf certificate
+	// configured via TLSSetting.CertFile/KeyFile. (optional, default false)
+	Enabled bool `mapstructure:"enabled"`
+
+	// CacheTTL bounds how long a fetched OCSP response is reused before it
+	// is refreshed, even if the responder's NextUpdate is further out. If
+	// zero, a response is only refreshed once NextUpdate is reached.
+	// (optional)
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+
+	// ResponderURLOverride replaces the OCSP responder URL otherwise taken
+	// from the leaf certificate's Authority Information Access extension.
+	// (optional)
+	ResponderURLOverride string `mapstructure:"responder_url_override"`
+
+	// RequireStapling, on a client, rejects the handshake unless the peer
+	// presents a stapled OCSP response, and rejects it if that response
+	// reports the peer's certificate as Revoked; an Unknown status is
+	// accepted. (optional, default false)
+	RequireStapling bool `mapstructure:"require_stapling"`
+}
+
+// ocspStapler prefetches and refreshes the OCSP response for the
+// certificate served by a certReloader, so handshakes can staple it without
+// a live round trip to the responder.
+type ocspStapler struct {
+	reloader *certReloader
+	setting  OCSPSetting
+	client   *http.Client
+
+	lock       sync.RWMutex
+	staple     []byte
+	nextReload time.Time
+
+	unregister func()
+}
+
+func newOCSPStapler(reloader *certReloader, setting OCSPSetting) (*ocspStapler, error) {
+	s := &ocspStapler{
+		reloader: reloader,
+		setting:  setting,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	s.unregister = register(s)
+	return s, nil
+}
+
+// GetStaple returns the cached OCSP response, refreshing it first if it is
+// due. A refresh failure is logged and the stale response is served rather
+// than failing the handshake, since an expired staple is still informative
+// to a client that chooses to reject it.
+func (s *ocspStapler) GetStaple() []byte {
+	s.lock.RLock()
+	due := !s.nextReload.IsZero() && time.Now().After(s.nextReload)
+	staple := s.staple
+	s.lock.RUnlock()
+	if !due {
+		return staple
+	}
+
+	if err := s.refresh(); err != nil {
+		zap.L().Error("failed to refresh OCSP staple, serving the last known response", zap.Error(err))
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.staple
+}
+
+// reload unconditionally refreshes the OCSP staple. It is called by
+// ReloadAll on SIGHUP.
+func (s *ocspStapler) reload() error {
+	return s.refresh()
+}
+
+func (s *ocspStapler) paths() []string {
+	return nil
+}
+
+// Close removes s from the package-level reload registry, so ReloadAll and
+// SIGHUP no longer touch it once the owning TLS config is no longer in use.
+func (s *ocspStapler) Close() error {
+	s.unregister()
+	return nil
+}
+
+func (s *ocspStapler) refresh() error {
+	cert, err := s.reloader.GetCertificate()
+	if err != nil {
+		return err
+	}
+	leaf, issuer, err := leafAndIssuer(cert)
+	if err != nil {
+		return err
+	}
+
+	responderURL := s.setting.ResponderURLOverride
+	if responderURL == "" {
+		if len(leaf.OCSPServer) == 0 {
+			return errors.New("leaf certificate advertises no OCSP responder and no responder_url_override was set")
+		}
+		responderURL = leaf.OCSPServer[0]
+	}
+
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build OCSP request: %w", err)
+	}
+	httpResp, err := s.client.Post(responderURL, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return fmt.Errorf("failed to reach OCSP responder %s: %w", responderURL, err)
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read OCSP response from %s: %w", responderURL, err)
+	}
+
+	resp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("failed to parse OCSP response from %s: %w", responderURL, err)
+	}
+	if resp.Status == ocsp.Revoked {
+		return fmt.Errorf("%w: serial %s", ErrCertificateRevoked, leaf.SerialNumber)
+	}
+
+	nextReload := resp.NextUpdate
+	if s.setting.CacheTTL != 0 {
+		if ttlDeadline := time.Now().Add(s.setting.CacheTTL); nextReload.IsZero() || ttlDeadline.Before(nextReload) {
+			nextReload = ttlDeadline
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.staple = body
+	s.nextReload = nextReload
+	return nil
+}
+
+// leafAndIssuer parses cert's leaf and issuer (the next certificate in its
+// chain) for use against the OCSP API, which needs both to build a request
+// and verify a response's signature.
+func leafAndIssuer(cert *tls.Certificate) (leaf, issuer *x509.Certificate, err error) {
+	if len(cert.Certificate) == 0 {
+		return nil, nil, errors.New("certificate has no leaf to staple an OCSP response for")
+	}
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse leaf certificate: %w", err)
+	}
+	if len(cert.Certificate) < 2 {
+		return nil, nil, errors.New("certificate chain has no issuer to build an OCSP request against")
+	}
+	issuer, err = x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse issuer certificate: %w", err)
+	}
+	return leaf, issuer, nil
+}
+
+// crlChecker holds the union of revoked certificate serial numbers parsed
+// from TLSSetting.CRLFiles, refreshed on the same schedule as
+// TLSSetting.ReloadInterval via certReloader-style lazy checks, and
+// reloadable on demand through the package's SIGHUP/ReloadAll mechanism.
+type crlChecker struct {
+	files          []string
+	reloadInterval time.Duration
+
+	lock       sync.RWMutex
+	revoked    map[string]struct{}
+	nextReload time.Time
+
+	unregister func()
+}
+
+func newCRLChecker(files []string, reloadInterval time.Duration) (*crlChecker, error) {
+	c := &crlChecker{files: files, reloadInterval: reloadInterval}
+	if err := c.doReload(); err != nil {
+		return nil, err
+	}
+	c.nextReload = time.Now().Add(reloadInterval)
+	c.unregister = register(c)
+	watchSignalsForReload()
+	return c, nil
+}
+
+func (c *crlChecker) paths() []string {
+	return c.files
+}
+
+// Close removes c from the package-level reload registry, so ReloadAll and
+// SIGHUP no longer touch it once the owning TLS config is no longer in use.
+func (c *crlChecker) Close() error {
+	c.unregister()
+	return nil
+}
+
+// reload unconditionally re-parses c.files. It is called by ReloadAll on
+// SIGHUP.
+func (c *crlChecker) reload() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.doReload()
+}
+
+func (c *crlChecker) doReload() error {
+	revoked := make(map[string]struct{})
+	for _, path := range c.files {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("failed to read CRL %s: %w", path, err)
+		}
+		der := data
+		if block, _ := pem.Decode(data); block != nil {
+			der = block.Bytes
+		}
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return fmt.Errorf("failed to parse CRL %s: %w", path, err)
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[entry.SerialNumber.String()] = struct{}{}
+		}
+	}
+	c.revoked = revoked
+	return nil
+}
+
+// checkChain refreshes the CRL set if ReloadInterval has elapsed, then
+// returns ErrCertificateRevoked if any certificate in chain is present in
+// one of the configured CRLs.
+func (c *crlChecker) checkChain(chain []*x509.Certificate) error {
+	c.lock.RLock()
+	due := c.reloadInterval != 0 && time.Now().After(c.nextReload)
+	c.lock.RUnlock()
+	if due {
+		if err := c.reload(); err != nil {
+			zap.L().Error("failed to reload CRLs, checking against the last known set", zap.Error(err))
+		} else {
+			c.lock.Lock()
+			c.nextReload = time.Now().Add(c.reloadInterval)
+			c.lock.Unlock()
+		}
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, cert := range chain {
+		if _, ok := c.revoked[cert.SerialNumber.String()]; ok {
+			return fmt.Errorf("%w: serial %s", ErrCertificateRevoked, cert.SerialNumber)
+		}
+	}
+	return nil
+}
+
+// verifiedOrPeerChain prefers the chain crypto/tls verified against RootCAs;
+// when normal verification was bypassed (e.g. by the live-RootCAs rotation
+// path, which sets InsecureSkipVerify), it falls back to the raw peer
+// certificates presented during the handshake.
+func verifiedOrPeerChain(cs tls.ConnectionState) []*x509.Certificate {
+	if len(cs.VerifiedChains) > 0 {
+		return cs.VerifiedChains[0]
+	}
+	return cs.PeerCertificates
+}
+
+// checkOCSPStaple enforces TLSSetting.OCSP.RequireStapling: it fails if cs
+// carries no stapled OCSP response, or one that reports the peer's leaf
+// certificate as Revoked. A response with an Unknown status is accepted.
+func checkOCSPStaple(cs tls.ConnectionState) error {
+	if len(cs.OCSPResponse) == 0 {
+		return ErrOCSPStaplingRequired
+	}
+	chain := verifiedOrPeerChain(cs)
+	if len(chain) == 0 {
+		return errors.New("no peer certificate to validate the OCSP staple against")
+	}
+	leaf := chain[0]
+	issuer := leaf
+	if len(chain) > 1 {
+		issuer = chain[1]
+	}
+
+	resp, err := ocsp.ParseResponseForCert(cs.OCSPResponse, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("failed to parse stapled OCSP response: %w", err)
+	}
+	if resp.Status == ocsp.Revoked {
+		return fmt.Errorf("%w: serial %s", ErrCertificateRevoked, leaf.SerialNumber)
+	}
+	return nil
+}
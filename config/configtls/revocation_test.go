@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCRL(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "revoked.crl.pem")
+	require.NoError(t, os.WriteFile(path, []byte(testCRLPEM), 0o600))
+	return path
+}
+
+func TestCRLCheckerChecksChain(t *testing.T) {
+	checker, err := newCRLChecker([]string{writeTestCRL(t)}, 0)
+	require.NoError(t, err)
+
+	revoked := &x509.Certificate{SerialNumber: big.NewInt(42)}
+	err = checker.checkChain([]*x509.Certificate{revoked})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCertificateRevoked)
+
+	clean := &x509.Certificate{SerialNumber: big.NewInt(7)}
+	require.NoError(t, checker.checkChain([]*x509.Certificate{clean}))
+}
+
+func TestCRLCheckerInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "not-a-crl.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a crl"), 0o600))
+
+	_, err := newCRLChecker([]string{path}, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse CRL")
+}
+
+func TestVerifiedOrPeerChain(t *testing.T) {
+	leaf := &x509.Certificate{SerialNumber: big.NewInt(1)}
+
+	withVerified := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+	assert.Equal(t, []*x509.Certificate{leaf}, verifiedOrPeerChain(withVerified))
+
+	withPeerOnly := tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	assert.Equal(t, []*x509.Certificate{leaf}, verifiedOrPeerChain(withPeerOnly))
+}
+
+func TestCheckOCSPStapleMissing(t *testing.T) {
+	err := checkOCSPStaple(tls.ConnectionState{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrOCSPStaplingRequired)
+}
+
+func TestLoadTLSConfigCRLFilesWiresVerifyConnection(t *testing.T) {
+	clientSetting := TLSClientSetting{
+		TLSSetting: TLSSetting{
+			CRLFiles: []string{writeTestCRL(t)},
+		},
+	}
+	cfg, _, err := clientSetting.LoadTLSConfig()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.VerifyConnection)
+
+	revoked := &x509.Certificate{SerialNumber: big.NewInt(42)}
+	err = cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{revoked}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCertificateRevoked)
+}
+
+func TestLeafAndIssuer(t *testing.T) {
+	block, _ := pem.Decode([]byte(testCertPEM))
+	require.NotNil(t, block)
+
+	_, _, err := leafAndIssuer(&tls.Certificate{Certificate: [][]byte{block.Bytes}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no issuer")
+
+	_, _, err = leafAndIssuer(&tls.Certificate{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no leaf")
+}
+
+// testCRLPEM is a throwaway CRL, signed by a one-off self-signed CA, that
+// revokes serial number 42; used only by these tests.
+const testCRLPEM = `-----BEGIN X509 CRL-----
+MIHhMIGIAgEBMAoGCCqGSM49BAMCMBIxEDAOBgNVBAMTB3Rlc3QtY2EXDTI2MDcy
+NzE4MDIwM1oXDTI2MDcyNzE5MDIwM1owFDASAgEqFw0yNjA3MjcxODAyMDNaoC8w
+LTAfBgNVHSMEGDAWgBTw8hgZHpJj1TBxE3uTXtcj+2I9nDAKBgNVHRQEAwIBATAK
+BggqhkjOPQQDAgNIADBFAiEA01P3yoa8KON33v7AfoeNUSc+tVpIU0fL4qcX7nnZ
+UokCIHZ0Db1xsyFmH8pf2UdYH3HDG37pqpD19uWR2U3spgL5
+-----END X509 CRL-----
+`
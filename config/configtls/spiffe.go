@@ -0,0 +1,264 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls // import "go.opentelemetry.io/collector/config/configtls"
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+// SPIFFESetting configures a SPIFFE Workload API source of workload identity,
+// used in place of file-based TLS material. See TLSSetting.SPIFFE.
+type SPIFFESetting struct {
+	// WorkloadAPISocket is the address of a local SPIRE agent's Workload API,
+	// e.g. "unix:///run/spire/sockets/agent.sock". Setting this enables
+	// SPIFFE in place of file-based CAFile/CertFile/KeyFile loading; setting
+	// it together with any of those fields is an error. (optional)
+	WorkloadAPISocket string `mapstructure:"workload_api_socket"`
+
+	// TrustDomain restricts which trust bundle is fetched to verify peers
+	// and populate RootCAs/ClientCAs. If empty, the trust domain of the
+	// workload's own X.509-SVID is used. (optional)
+	TrustDomain string `mapstructure:"trust_domain"`
+
+	// AcceptedIDs lists the SPIFFE IDs a peer certificate must present,
+	// checked in place of hostname verification. An entry of the form
+	// "spiffe://<trust-domain>/*" accepts any ID in that trust domain; any
+	// other entry must match the peer's ID exactly. At least one entry is
+	// required when WorkloadAPISocket is set.
+	AcceptedIDs []string `mapstructure:"accepted_ids"`
+}
+
+// enabled reports whether SPIFFE-based identity should replace file-based
+// CAFile/CertFile/KeyFile loading.
+func (s SPIFFESetting) enabled() bool {
+	return s.WorkloadAPISocket != ""
+}
+
+// spiffeSource fetches X.509-SVIDs and trust bundles from a SPIRE agent over
+// the SPIFFE Workload API. The underlying workloadapi.X509Source keeps a
+// background stream to the agent open and updates its in-memory SVID and
+// bundle as SPIRE rotates them, so every call to getCertificate or rootCAs
+// reflects the latest material without polling or a restart.
+type spiffeSource struct {
+	client      *workloadapi.X509Source
+	trustDomain spiffeid.TrustDomain
+}
+
+func newSPIFFESource(setting SPIFFESetting) (*spiffeSource, error) {
+	ctx := context.Background()
+	client, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(setting.WorkloadAPISocket)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SPIFFE Workload API at %s: %w", setting.WorkloadAPISocket, err)
+	}
+
+	trustDomain := spiffeid.TrustDomain{}
+	if setting.TrustDomain != "" {
+		trustDomain, err = spiffeid.TrustDomainFromString(setting.TrustDomain)
+		if err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("invalid spiffe.trust_domain %q: %w", setting.TrustDomain, err)
+		}
+	} else {
+		svid, svidErr := client.GetX509SVID()
+		if svidErr != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("failed to fetch initial X.509-SVID: %w", svidErr)
+		}
+		trustDomain = svid.ID.TrustDomain()
+	}
+
+	return &spiffeSource{client: client, trustDomain: trustDomain}, nil
+}
+
+// Close closes the underlying Workload API connection. Callers must call it
+// once the spiffeSource is no longer in use, since it otherwise holds the
+// stream to the SPIRE agent open for the life of the process.
+func (s *spiffeSource) Close() error {
+	return s.client.Close()
+}
+
+// getCertificate returns the workload's current X.509-SVID as a
+// tls.Certificate, re-fetched from the source on every call.
+func (s *spiffeSource) getCertificate() (*tls.Certificate, error) {
+	svid, err := s.client.GetX509SVID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch X.509-SVID: %w", err)
+	}
+	raw := make([][]byte, len(svid.Certificates))
+	for i, cert := range svid.Certificates {
+		raw[i] = cert.Raw
+	}
+	return &tls.Certificate{Certificate: raw, PrivateKey: svid.PrivateKey}, nil
+}
+
+// GetCertificate is a tls.Config.GetCertificate callback serving the
+// workload's current X.509-SVID as a server certificate.
+func (s *spiffeSource) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.getCertificate()
+}
+
+// GetClientCertificate is a tls.Config.GetClientCertificate callback serving
+// the workload's current X.509-SVID as a client certificate.
+func (s *spiffeSource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.getCertificate()
+}
+
+// rootCAs returns a fresh x509.CertPool built from the current trust bundle
+// for s.trustDomain.
+func (s *spiffeSource) rootCAs() (*x509.CertPool, error) {
+	bundle, err := s.client.GetX509BundleForTrustDomain(s.trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SPIFFE trust bundle for %s: %w", s.trustDomain, err)
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range bundle.X509Authorities() {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// newSPIFFEIDVerifier builds a check that a peer leaf certificate's SPIFFE ID
+// (its sole URI SAN) is allowed by acceptedIDs. It fails fast on malformed
+// entries so a typo in the config is reported at startup rather than on the
+// first handshake.
+func newSPIFFEIDVerifier(acceptedIDs []string) (func(*x509.Certificate) error, error) {
+	if len(acceptedIDs) == 0 {
+		return nil, errors.New("spiffe.accepted_ids must list at least one SPIFFE ID or trust-domain wildcard")
+	}
+
+	exact := make(map[string]struct{}, len(acceptedIDs))
+	var wildcardDomains []spiffeid.TrustDomain
+	for _, id := range acceptedIDs {
+		if rest, ok := strings.CutSuffix(id, "/*"); ok {
+			td, err := spiffeid.TrustDomainFromString(strings.TrimPrefix(rest, "spiffe://"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid accepted_ids trust-domain wildcard %q: %w", id, err)
+			}
+			wildcardDomains = append(wildcardDomains, td)
+			continue
+		}
+		if _, err := spiffeid.FromString(id); err != nil {
+			return nil, fmt.Errorf("invalid accepted_ids entry %q: %w", id, err)
+		}
+		exact[id] = struct{}{}
+	}
+
+	return func(cert *x509.Certificate) error {
+		peerID, err := x509svid.IDFromCert(cert)
+		if err != nil {
+			return fmt.Errorf("peer certificate does not carry a SPIFFE ID: %w", err)
+		}
+		if _, ok := exact[peerID.String()]; ok {
+			return nil
+		}
+		for _, td := range wildcardDomains {
+			if peerID.MemberOf(td) {
+				return nil
+			}
+		}
+		return fmt.Errorf("peer SPIFFE ID %q is not allowed by accepted_ids", peerID.String())
+	}, nil
+}
+
+// wireSPIFFE replaces c.CertFile/KeyFile and peer hostname verification on
+// tlsCfg with SPIFFE Workload API-backed equivalents. crypto/tls has no
+// client-side analogue of GetConfigForClient, so the live trust bundle is
+// verified against explicitly in VerifyPeerCertificate, following the same
+// pattern as wireClientRootCAsReload. The returned io.Closer releases the
+// Workload API connection; callers must Close it once tlsCfg is no longer
+// in use.
+func (c TLSClientSetting) wireSPIFFE(tlsCfg *tls.Config) (io.Closer, error) {
+	if c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" {
+		return nil, errors.New("spiffe cannot be combined with ca_file, cert_file or key_file")
+	}
+
+	src, err := newSPIFFESource(c.SPIFFE)
+	if err != nil {
+		return nil, err
+	}
+	verifyID, err := newSPIFFEIDVerifier(c.SPIFFE.AcceptedIDs)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+
+	tlsCfg.GetClientCertificate = src.GetClientCertificate
+	tlsCfg.InsecureSkipVerify = true
+	tlsCfg.RootCAs = nil
+	tlsCfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		leaf, intermediates, err := parsePeerCertificates(rawCerts)
+		if err != nil {
+			return err
+		}
+		roots, err := src.rootCAs()
+		if err != nil {
+			return err
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return fmt.Errorf("failed to verify peer certificate against SPIFFE trust bundle: %w", err)
+		}
+		return verifyID(leaf)
+	}
+	return src, nil
+}
+
+// wireSPIFFE replaces c.CertFile/KeyFile, c.ClientCAFile and
+// ClientAuthPolicy-based verification on tlsCfg with SPIFFE Workload
+// API-backed equivalents. ClientCAs is refreshed per-handshake via
+// GetConfigForClient so a rotated trust bundle is honored without
+// restarting the listener, mirroring how caRel/clientCAsRel are wired in
+// TLSServerSetting.LoadTLSConfig.
+// The returned io.Closer releases the Workload API connection; callers must
+// Close it once tlsCfg is no longer in use.
+func (c TLSServerSetting) wireSPIFFE(tlsCfg *tls.Config) (io.Closer, error) {
+	if c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.ClientCAFile != "" {
+		return nil, errors.New("spiffe cannot be combined with ca_file, cert_file, key_file or client_ca_file")
+	}
+	if !c.ClientAuthPolicy.empty() {
+		return nil, errors.New("spiffe cannot be combined with client_auth_policy; use accepted_ids instead")
+	}
+
+	src, err := newSPIFFESource(c.SPIFFE)
+	if err != nil {
+		return nil, err
+	}
+	verifyID, err := newSPIFFEIDVerifier(c.SPIFFE.AcceptedIDs)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+
+	tlsCfg.GetCertificate = src.GetCertificate
+	tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsCfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		roots, err := src.rootCAs()
+		if err != nil {
+			return nil, err
+		}
+		cfg := tlsCfg.Clone()
+		cfg.ClientCAs = roots
+		return cfg, nil
+	}
+	tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("client did not present a certificate")
+		}
+		return verifyID(cs.PeerCertificates[0])
+	}
+	return src, nil
+}
@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package configtls
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func certWithSPIFFEID(t *testing.T, id string) *x509.Certificate {
+	t.Helper()
+	u, err := url.Parse(id)
+	require.NoError(t, err)
+	return &x509.Certificate{URIs: []*url.URL{u}}
+}
+
+func TestSPIFFESettingEnabled(t *testing.T) {
+	assert.False(t, SPIFFESetting{}.enabled())
+	assert.True(t, SPIFFESetting{WorkloadAPISocket: "unix:///run/spire/sockets/agent.sock"}.enabled())
+}
+
+func TestNewSPIFFEIDVerifierNoAcceptedIDs(t *testing.T) {
+	_, err := newSPIFFEIDVerifier(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "accepted_ids must list at least one")
+}
+
+func TestNewSPIFFEIDVerifierInvalidEntry(t *testing.T) {
+	_, err := newSPIFFEIDVerifier([]string{"not-a-spiffe-id"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid accepted_ids entry")
+}
+
+func TestNewSPIFFEIDVerifierInvalidWildcard(t *testing.T) {
+	_, err := newSPIFFEIDVerifier([]string{"spiffe:// /*"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid accepted_ids trust-domain wildcard")
+}
+
+func TestSPIFFEIDVerifierExactMatch(t *testing.T) {
+	verify, err := newSPIFFEIDVerifier([]string{"spiffe://example.org/my-service"})
+	require.NoError(t, err)
+
+	require.NoError(t, verify(certWithSPIFFEID(t, "spiffe://example.org/my-service")))
+
+	err = verify(certWithSPIFFEID(t, "spiffe://example.org/other-service"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not allowed by accepted_ids")
+}
+
+func TestSPIFFEIDVerifierTrustDomainWildcard(t *testing.T) {
+	verify, err := newSPIFFEIDVerifier([]string{"spiffe://example.org/*"})
+	require.NoError(t, err)
+
+	require.NoError(t, verify(certWithSPIFFEID(t, "spiffe://example.org/my-service")))
+	require.NoError(t, verify(certWithSPIFFEID(t, "spiffe://example.org/other/service")))
+
+	err = verify(certWithSPIFFEID(t, "spiffe://other.org/my-service"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not allowed by accepted_ids")
+}
+
+func TestSPIFFEIDVerifierRejectsCertWithoutSPIFFEID(t *testing.T) {
+	verify, err := newSPIFFEIDVerifier([]string{"spiffe://example.org/*"})
+	require.NoError(t, err)
+
+	err = verify(testLeafCert(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not carry a SPIFFE ID")
+}
+
+func TestWireSPIFFERejectsFileBasedFields(t *testing.T) {
+	clientSetting := TLSClientSetting{
+		TLSSetting: TLSSetting{
+			CAFile: "ca.pem",
+			SPIFFE: SPIFFESetting{WorkloadAPISocket: "unix:///run/spire/sockets/agent.sock"},
+		},
+	}
+	_, err := clientSetting.wireSPIFFE(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined with")
+
+	serverSetting := TLSServerSetting{
+		TLSSetting:   TLSSetting{SPIFFE: SPIFFESetting{WorkloadAPISocket: "unix:///run/spire/sockets/agent.sock"}},
+		ClientCAFile: "ca.pem",
+	}
+	_, err = serverSetting.wireSPIFFE(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined with")
+}
+
+func TestWireSPIFFERejectsClientAuthPolicy(t *testing.T) {
+	serverSetting := TLSServerSetting{
+		TLSSetting:       TLSSetting{SPIFFE: SPIFFESetting{WorkloadAPISocket: "unix:///run/spire/sockets/agent.sock"}},
+		ClientAuthPolicy: ClientAuthPolicy{AllowedSubjectCommonNames: []string{"test"}},
+	}
+	_, err := serverSetting.wireSPIFFE(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined with client_auth_policy")
+}